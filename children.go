@@ -0,0 +1,136 @@
+package kradix
+
+import "sort"
+
+// childList stores a node's children, keyed by the first byte of each
+// child's edge label. It is implemented by sparseChildren for nodes with
+// few children and denseChildren for nodes with many, so a node only pays
+// for a 256-wide array once it actually fans out that much.
+type childList[T any] interface {
+	get(b byte) *node[T]
+	set(b byte, child *node[T])
+	remove(b byte)
+	len() int
+	// each calls fn once per child, in ascending byte order.
+	each(fn func(b byte, child *node[T]))
+	// clone returns a shallow copy of the list: the same child pointers,
+	// but a new backing slice/array so set/remove on the copy doesn't
+	// affect the original. Used by the immutable tree's copy-on-write Txn.
+	clone() childList[T]
+}
+
+func newChildren[T any]() childList[T] {
+	return &sparseChildren[T]{}
+}
+
+type sparseEntry[T any] struct {
+	b byte
+	n *node[T]
+}
+
+// sparseChildren stores children as a linearly-scanned, byte-sorted slice.
+// It is cheap for the common case of a node with only a handful of
+// children, costing O(children) per lookup instead of a fixed 256 pointers.
+type sparseChildren[T any] struct {
+	entries []sparseEntry[T]
+}
+
+func (s *sparseChildren[T]) get(b byte) *node[T] {
+	for i := range s.entries {
+		if s.entries[i].b == b {
+			return s.entries[i].n
+		}
+	}
+	return nil
+}
+
+func (s *sparseChildren[T]) set(b byte, child *node[T]) {
+	i := sort.Search(len(s.entries), func(i int) bool { return s.entries[i].b >= b })
+	if i < len(s.entries) && s.entries[i].b == b {
+		s.entries[i].n = child
+		return
+	}
+	s.entries = append(s.entries, sparseEntry[T]{})
+	copy(s.entries[i+1:], s.entries[i:])
+	s.entries[i] = sparseEntry[T]{b: b, n: child}
+}
+
+func (s *sparseChildren[T]) remove(b byte) {
+	for i := range s.entries {
+		if s.entries[i].b == b {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *sparseChildren[T]) len() int {
+	return len(s.entries)
+}
+
+func (s *sparseChildren[T]) each(fn func(b byte, child *node[T])) {
+	for _, e := range s.entries {
+		fn(e.b, e.n)
+	}
+}
+
+func (s *sparseChildren[T]) clone() childList[T] {
+	return &sparseChildren[T]{entries: append([]sparseEntry[T]{}, s.entries...)}
+}
+
+// denseChildren is the original byte-indexed array, used once a node fans
+// out enough children that linear scanning would cost more than the array
+// saves in memory.
+type denseChildren[T any] struct {
+	edges [branchingFactor]*node[T]
+	n     int
+}
+
+func (d *denseChildren[T]) get(b byte) *node[T] {
+	return d.edges[b]
+}
+
+func (d *denseChildren[T]) set(b byte, child *node[T]) {
+	if d.edges[b] == nil && child != nil {
+		d.n++
+	} else if d.edges[b] != nil && child == nil {
+		d.n--
+	}
+	d.edges[b] = child
+}
+
+func (d *denseChildren[T]) remove(b byte) {
+	if d.edges[b] != nil {
+		d.edges[b] = nil
+		d.n--
+	}
+}
+
+func (d *denseChildren[T]) len() int {
+	return d.n
+}
+
+func (d *denseChildren[T]) each(fn func(b byte, child *node[T])) {
+	for i, e := range d.edges {
+		if e != nil {
+			fn(byte(i), e)
+		}
+	}
+}
+
+func (d *denseChildren[T]) clone() childList[T] {
+	c := *d
+	return &c
+}
+
+func sparseToDense[T any](s *sparseChildren[T]) *denseChildren[T] {
+	d := &denseChildren[T]{}
+	s.each(func(b byte, child *node[T]) { d.set(b, child) })
+	return d
+}
+
+func denseToSparse[T any](d *denseChildren[T]) *sparseChildren[T] {
+	s := &sparseChildren[T]{entries: make([]sparseEntry[T], 0, d.len())}
+	d.each(func(b byte, child *node[T]) { s.entries = append(s.entries, sparseEntry[T]{b: b, n: child}) })
+	return s
+}
@@ -0,0 +1,89 @@
+package kradix
+
+import "testing"
+
+// childKindOf reports which storage a node is currently using, for tests
+// that need to assert promotion/demotion actually happened.
+func childKindOf[T any](n *node[T]) string {
+	switch n.kids.(type) {
+	case *sparseChildren[T]:
+		return "sparse"
+	case *denseChildren[T]:
+		return "dense"
+	default:
+		return "unknown"
+	}
+}
+
+func TestSparseToDensePromotion(t *testing.T) {
+	r := New[int](Config{SparseThreshold: 4})
+
+	for i := 0; i < 4; i++ {
+		r.Insert(string(rune('a'+i)), i)
+	}
+	if kind := childKindOf(r.root); kind != "sparse" {
+		t.Fatalf("root storage = %s after 4 children with threshold 4, want sparse", kind)
+	}
+
+	r.Insert("e", 4)
+	if kind := childKindOf(r.root); kind != "dense" {
+		t.Fatalf("root storage = %s after 5 children with threshold 4, want dense", kind)
+	}
+
+	for i, want := range []int{0, 1, 2, 3, 4} {
+		got, ok := r.Get(string(rune('a' + i)))
+		if !ok || got != want {
+			t.Errorf("Get(%q) = (%d, %v), want (%d, true)", string(rune('a'+i)), got, ok, want)
+		}
+	}
+}
+
+func TestDenseToSparseDemotion(t *testing.T) {
+	r := New[int](Config{SparseThreshold: 4})
+
+	for i := 0; i < 5; i++ {
+		r.Insert(string(rune('a'+i)), i)
+	}
+	if kind := childKindOf(r.root); kind != "dense" {
+		t.Fatalf("root storage = %s, want dense", kind)
+	}
+
+	// Demotion happens once the live-child count falls below threshold/2.
+	r.Delete("e")
+	r.Delete("d")
+	r.Delete("c")
+	r.Delete("b")
+	if kind := childKindOf(r.root); kind != "sparse" {
+		t.Fatalf("root storage = %s after shrinking to 1 child, want sparse", kind)
+	}
+
+	if got, ok := r.Get("a"); !ok || got != 0 {
+		t.Errorf("Get(\"a\") = (%d, %v), want (0, true)", got, ok)
+	}
+}
+
+// TestHighByteKeysPromoteToDense guards against the dense array being sized
+// for 7-bit bytes: keys with a leading byte >= 0x80 are routine in UTF-8
+// paths and URLs, and must not panic once a node fans out enough to be
+// promoted to denseChildren.
+func TestHighByteKeysPromoteToDense(t *testing.T) {
+	r := New[int](Config{SparseThreshold: 4})
+
+	for i := 0; i < 20; i++ {
+		key := string([]byte{byte(128 + i)})
+		r.Insert(key, i)
+	}
+	if kind := childKindOf(r.root); kind != "dense" {
+		t.Fatalf("root storage = %s after 20 children, want dense", kind)
+	}
+
+	for i := 0; i < 20; i++ {
+		key := string([]byte{byte(128 + i)})
+		if got, ok := r.Get(key); !ok || got != i {
+			t.Errorf("Get(%q) = (%d, %v), want (%d, true)", key, got, ok, i)
+		}
+	}
+	if r.Len() != 20 {
+		t.Fatalf("Len() = %d, want 20", r.Len())
+	}
+}
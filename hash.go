@@ -0,0 +1,205 @@
+package kradix
+
+import (
+	"bytes"
+	"hash"
+)
+
+// NewHashed returns an empty RadixTree in Merkle-hashed mode: every node
+// lazily caches a digest over its label, its value (via valueHash) and its
+// children's digests, so Root and Diff can summarize or compare the whole
+// tree in time proportional to what changed rather than to its size.
+func NewHashed[T any](hasher func() hash.Hash, valueHash func(T) []byte) *RadixTree[T] {
+	t := New[T]()
+	t.hasher = hasher
+	t.valueHash = valueHash
+	return t
+}
+
+// digest returns n's cached Merkle digest, computing it on demand as
+// H(label || terminal flag || valueHash(value) || H(child_0) || ... ||
+// H(child_k)) with children visited in sorted byte order; valueHash is
+// included only for terminal nodes. The result is
+// cached on n until a mutation along n's path clears it.
+func (t *RadixTree[T]) digest(n *node[T]) []byte {
+	if n.digest != nil {
+		return n.digest
+	}
+
+	h := t.hasher()
+	h.Write(n.label)
+	if n.terminal {
+		h.Write([]byte{1})
+		h.Write(t.valueHash(n.value))
+	} else {
+		h.Write([]byte{0})
+	}
+	n.kids.each(func(_ byte, child *node[T]) {
+		h.Write(t.digest(child))
+	})
+
+	n.digest = h.Sum(nil)
+	return n.digest
+}
+
+// Root returns the tree's root digest, computing it on demand. It returns
+// nil if the tree was not created with NewHashed.
+func (t *RadixTree[T]) Root() []byte {
+	if t.hasher == nil {
+		return nil
+	}
+	return t.digest(t.root)
+}
+
+// Diff compares t against other, both of which must have been created with
+// NewHashed using compatible hasher and valueHash functions, and reports
+// which keys were added, removed, or changed in other relative to t. It
+// walks both trees in lockstep and prunes any pair of subtrees whose
+// digests already match, so the cost is proportional to the number of
+// differences rather than to the size of either tree.
+func (t *RadixTree[T]) Diff(other *RadixTree[T]) (added, removed, changed []string) {
+	t.diffNodes(t.root, other.root, 0, 0, nil, &added, &removed, &changed)
+	return added, removed, changed
+}
+
+// diffNodes compares the subtree rooted at na, past offA bytes of its own
+// label, against the subtree rooted at nb, past offB bytes of its label.
+// The two offsets let the walk realign when one tree splits an edge that
+// the other doesn't: rather than giving up as soon as the labels differ,
+// it walks the shorter remaining label against the longer one and only
+// treats the pair as a wholesale replacement once their next bytes
+// actually diverge.
+func (t *RadixTree[T]) diffNodes(na, nb *node[T], offA, offB int, prefix []byte, added, removed, changed *[]string) {
+	if na == nil && nb == nil {
+		return
+	}
+	if na == nil {
+		collectKeys(nb, append(append([]byte{}, prefix...), nb.label[offB:]...), added)
+		return
+	}
+	if nb == nil {
+		collectKeys(na, append(append([]byte{}, prefix...), na.label[offA:]...), removed)
+		return
+	}
+
+	startA, startB := offA, offB
+	remA, remB := na.label[offA:], nb.label[offB:]
+	cpl := commonPrefixLen(remA, remB)
+	extended := append(append([]byte{}, prefix...), remA[:cpl]...)
+	offA, offB = offA+cpl, offB+cpl
+	atEndA, atEndB := offA == len(na.label), offB == len(nb.label)
+
+	switch {
+	case atEndA && atEndB:
+		// Both sides reached a real node exactly together. The cached
+		// digests are only comparable when na and nb were whole nodes to
+		// begin with (offsets of 0): a digest covers a node's full label,
+		// so it can't stand in for a partial match realigned mid-label.
+		if startA == 0 && startB == 0 && bytes.Equal(t.digest(na), t.digest(nb)) {
+			return
+		}
+
+		switch {
+		case na.terminal && !nb.terminal:
+			*removed = append(*removed, string(extended))
+		case !na.terminal && nb.terminal:
+			*added = append(*added, string(extended))
+		case na.terminal && nb.terminal && !bytes.Equal(t.valueHash(na.value), t.valueHash(nb.value)):
+			*changed = append(*changed, string(extended))
+		}
+
+		for _, b := range unionChildBytes(na, nb) {
+			t.diffNodes(na.kids.get(b), nb.kids.get(b), 0, 0, extended, added, removed, changed)
+		}
+
+	case atEndA:
+		// na's label is fully consumed here but nb's label continues: nb
+		// splits this edge somewhere na doesn't. na itself has no
+		// counterpart node in b's tree at this exact point, only a
+		// further descent along nb, so compare na's own key against
+		// "absent" and recurse into na's real children plus the single
+		// byte nb continues on.
+		if na.terminal {
+			*removed = append(*removed, string(extended))
+		}
+		next := nb.label[offB]
+		for _, b := range unionChildBytesWithExtra(na, next) {
+			if b == next {
+				// na's child at b and nb's continuation agree on this
+				// byte; it's now consumed on both sides, so fold it into
+				// the prefix and skip past it (offA=1) rather than
+				// re-matching it against na's child label from scratch.
+				t.diffNodes(na.kids.get(b), nb, 1, offB+1, append(append([]byte{}, extended...), b), added, removed, changed)
+			} else {
+				t.diffNodes(na.kids.get(b), nil, 0, 0, extended, added, removed, changed)
+			}
+		}
+
+	case atEndB:
+		// Mirror of the above: nb ends here, na continues.
+		if nb.terminal {
+			*added = append(*added, string(extended))
+		}
+		next := na.label[offA]
+		for _, b := range unionChildBytesWithExtra(nb, next) {
+			if b == next {
+				t.diffNodes(na, nb.kids.get(b), offA+1, 1, append(append([]byte{}, extended...), b), added, removed, changed)
+			} else {
+				t.diffNodes(nil, nb.kids.get(b), 0, 0, extended, added, removed, changed)
+			}
+		}
+
+	default:
+		// Neither label ends here and the next byte differs on each
+		// side: a genuine divergence, so nothing under na and nb can
+		// share a key.
+		collectKeys(na, append(append([]byte{}, extended...), na.label[offA:]...), removed)
+		collectKeys(nb, append(append([]byte{}, extended...), nb.label[offB:]...), added)
+	}
+}
+
+// unionChildBytes returns, in ascending order, every byte that keys a
+// child on either na or nb.
+func unionChildBytes[T any](na, nb *node[T]) []byte {
+	var seen [branchingFactor]bool
+	na.kids.each(func(b byte, _ *node[T]) { seen[b] = true })
+	nb.kids.each(func(b byte, _ *node[T]) { seen[b] = true })
+
+	return setBytes(seen)
+}
+
+// unionChildBytesWithExtra returns, in ascending order, every byte that
+// keys a child on n, plus extra itself. Used when realigning a node whose
+// label ended against another tree's edge that continues past it: extra
+// is the byte the other tree continues on, which may or may not coincide
+// with one of n's own child bytes.
+func unionChildBytesWithExtra[T any](n *node[T], extra byte) []byte {
+	var seen [branchingFactor]bool
+	n.kids.each(func(b byte, _ *node[T]) { seen[b] = true })
+	seen[extra] = true
+
+	return setBytes(seen)
+}
+
+func setBytes(seen [branchingFactor]bool) []byte {
+	out := make([]byte, 0, branchingFactor)
+	for b, ok := range seen {
+		if ok {
+			out = append(out, byte(b))
+		}
+	}
+	return out
+}
+
+// collectKeys appends every terminal key under n, in sorted order, to out.
+func collectKeys[T any](n *node[T], prefix []byte, out *[]string) {
+	if n == nil {
+		return
+	}
+	if n.terminal {
+		*out = append(*out, string(prefix))
+	}
+	n.kids.each(func(_ byte, child *node[T]) {
+		collectKeys(child, append(append([]byte{}, prefix...), child.label...), out)
+	})
+}
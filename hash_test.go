@@ -0,0 +1,153 @@
+package kradix
+
+import (
+	"crypto/sha256"
+	"hash"
+	"sort"
+	"testing"
+)
+
+func newTestHasher() hash.Hash { return sha256.New() }
+
+func testValueHash(v int) []byte { return []byte{byte(v)} }
+
+func TestRootIsDeterministic(t *testing.T) {
+	a := NewHashed[int](newTestHasher, testValueHash)
+	a.Insert("abc", 1)
+	a.Insert("abd", 2)
+	a.Insert("xyz", 3)
+
+	b := NewHashed[int](newTestHasher, testValueHash)
+	b.Insert("xyz", 3)
+	b.Insert("abd", 2)
+	b.Insert("abc", 1)
+
+	if string(a.Root()) != string(b.Root()) {
+		t.Fatalf("Root() differs between two trees built from the same keys in different order")
+	}
+}
+
+func TestRootSurvivesIntermediateCalls(t *testing.T) {
+	// Calling Root() between inserts must not change the final digest: a
+	// split that happens after Root() has cached a child's digest needs to
+	// invalidate that cache too.
+	withIntermediateCall := NewHashed[int](newTestHasher, testValueHash)
+	withIntermediateCall.Insert("abc", 1)
+	_ = withIntermediateCall.Root()
+	withIntermediateCall.Insert("abd", 2)
+
+	fresh := NewHashed[int](newTestHasher, testValueHash)
+	fresh.Insert("abc", 1)
+	fresh.Insert("abd", 2)
+
+	if string(withIntermediateCall.Root()) != string(fresh.Root()) {
+		t.Fatal("Root() called mid-build produced a stale digest after a later split")
+	}
+}
+
+func TestRootChangesOnMutation(t *testing.T) {
+	r := NewHashed[int](newTestHasher, testValueHash)
+	r.Insert("a", 1)
+	before := r.Root()
+
+	r.Insert("b", 2)
+	after := r.Root()
+
+	if string(before) == string(after) {
+		t.Fatal("Root() unchanged after inserting a new key")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := NewHashed[int](newTestHasher, testValueHash)
+	a.Insert("apple", 1)
+	a.Insert("app", 2)
+	a.Insert("banana", 3)
+
+	b := NewHashed[int](newTestHasher, testValueHash)
+	b.Insert("apple", 1)
+	b.Insert("app", 5) // changed
+	b.Insert("cherry", 4) // added
+	// banana removed
+
+	added, removed, changed := a.Diff(b)
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+
+	if len(added) != 1 || added[0] != "cherry" {
+		t.Errorf("added = %v, want [cherry]", added)
+	}
+	if len(removed) != 1 || removed[0] != "banana" {
+		t.Errorf("removed = %v, want [banana]", removed)
+	}
+	if len(changed) != 1 || changed[0] != "app" {
+		t.Errorf("changed = %v, want [app]", changed)
+	}
+}
+
+func TestDiffRealignsAcrossMismatchedSplits(t *testing.T) {
+	// b has an extra key ("car") that forces a split of the edge leading
+	// to "cat", which a never had to split. The shared key "cat" must not
+	// be reported as both removed (from a's unsplit edge) and added (to
+	// b's split edge).
+	a := NewHashed[int](newTestHasher, testValueHash)
+	a.Insert("cat", 1)
+
+	b := NewHashed[int](newTestHasher, testValueHash)
+	b.Insert("cat", 1)
+	b.Insert("car", 2)
+
+	added, removed, changed := a.Diff(b)
+
+	sort.Strings(added)
+	if len(added) != 1 || added[0] != "car" {
+		t.Errorf("added = %v, want [car]", added)
+	}
+	if len(removed) != 0 {
+		t.Errorf("removed = %v, want none (\"cat\" is unchanged in both trees)", removed)
+	}
+	if len(changed) != 0 {
+		t.Errorf("changed = %v, want none", changed)
+	}
+}
+
+func TestDiffRealignsTheOtherDirection(t *testing.T) {
+	// Mirror of the above, with the split on the receiver side instead of
+	// the argument side.
+	a := NewHashed[int](newTestHasher, testValueHash)
+	a.Insert("cat", 1)
+	a.Insert("car", 2)
+
+	b := NewHashed[int](newTestHasher, testValueHash)
+	b.Insert("cat", 1)
+
+	added, removed, changed := a.Diff(b)
+
+	sort.Strings(removed)
+	if len(removed) != 1 || removed[0] != "car" {
+		t.Errorf("removed = %v, want [car]", removed)
+	}
+	if len(added) != 0 {
+		t.Errorf("added = %v, want none", added)
+	}
+	if len(changed) != 0 {
+		t.Errorf("changed = %v, want none", changed)
+	}
+}
+
+func TestDiffIdenticalTreesIsEmpty(t *testing.T) {
+	a := NewHashed[int](newTestHasher, testValueHash)
+	a.Insert("a", 1)
+	a.Insert("ab", 2)
+
+	b := NewHashed[int](newTestHasher, testValueHash)
+	b.Insert("ab", 2)
+	b.Insert("a", 1)
+
+	added, removed, changed := a.Diff(b)
+	if len(added)+len(removed)+len(changed) != 0 {
+		t.Fatalf("Diff of identical trees = added:%v removed:%v changed:%v, want all empty", added, removed, changed)
+	}
+}
@@ -0,0 +1,237 @@
+package kradix
+
+// ImmutableTree is a persistent, structurally-shared radix tree built from
+// the same node[T]/childList[T] types as RadixTree, so it gets the same
+// sparse/dense storage switch instead of paying for a full branchingFactor
+// array per node. Once a node is reachable from a committed tree it is
+// treated as read-only forever; a Txn clones a node the first time it
+// needs to change it and leaves every other subtree shared between the old
+// and new tree. A committed *ImmutableTree[T] never changes, so it can be
+// read concurrently from any number of goroutines without locking; new
+// versions are produced through a Txn.
+type ImmutableTree[T any] struct {
+	root *node[T]
+	size int
+}
+
+// NewImmutable returns an empty ImmutableTree.
+func NewImmutable[T any]() *ImmutableTree[T] {
+	return &ImmutableTree[T]{root: &node[T]{kids: newChildren[T]()}}
+}
+
+// Len returns the number of keys stored in the tree.
+func (t *ImmutableTree[T]) Len() int {
+	return t.size
+}
+
+// Get looks up key in the tree as of this snapshot.
+func (t *ImmutableTree[T]) Get(key string) (T, bool) {
+	n := t.root
+	for len(key) > 0 {
+		child := n.kids.get(key[0])
+		if child == nil || len(key) < len(child.label) || string(child.label) != key[:len(child.label)] {
+			return *new(T), false
+		}
+		key = key[len(child.label):]
+		n = child
+	}
+	return n.value, n.terminal
+}
+
+// Txn starts a new transaction against this snapshot. The receiver is left
+// untouched; call Commit on the returned Txn to obtain the new tree.
+func (t *ImmutableTree[T]) Txn() *Txn[T] {
+	return &Txn[T]{
+		root:   t.root,
+		size:   t.size,
+		cloned: make(map[*node[T]]*node[T]),
+	}
+}
+
+// txnCloneCap bounds how many entries a Txn's clone map holds (each clone
+// records its original and itself).
+// Past this, nodes are still cloned correctly but a node touched twice in
+// the same huge transaction may be cloned more than once, trading a little
+// extra allocation for a constant memory ceiling.
+const txnCloneCap = 8192
+
+// Txn is a mutable view over an ImmutableTree that performs copy-on-write:
+// a node is cloned the first time it is modified within the transaction,
+// and unmodified subtrees continue to be shared with the snapshot the
+// transaction started from.
+type Txn[T any] struct {
+	root   *node[T]
+	size   int
+	cloned map[*node[T]]*node[T]
+}
+
+func (txn *Txn[T]) clone(n *node[T]) *node[T] {
+	if c, ok := txn.cloned[n]; ok {
+		return c
+	}
+	c := &node[T]{
+		label:    n.label,
+		terminal: n.terminal,
+		value:    n.value,
+		kids:     n.kids.clone(),
+	}
+	if len(txn.cloned) < txnCloneCap {
+		txn.cloned[n] = c
+		// The clone is already private to this txn, so later changes to it
+		// must reuse it rather than clone it again.
+		txn.cloned[c] = c
+	}
+	return c
+}
+
+// Get looks up key, seeing any uncommitted writes made earlier in this
+// transaction.
+func (txn *Txn[T]) Get(key string) (T, bool) {
+	n := txn.root
+	for len(key) > 0 {
+		child := n.kids.get(key[0])
+		if child == nil || len(key) < len(child.label) || string(child.label) != key[:len(child.label)] {
+			return *new(T), false
+		}
+		key = key[len(child.label):]
+		n = child
+	}
+	return n.value, n.terminal
+}
+
+func (txn *Txn[T]) Insert(key string, value T) {
+	txn.root = txn.insert(txn.root, key, value)
+}
+
+func (txn *Txn[T]) insert(n *node[T], key string, value T) *node[T] {
+	n = txn.clone(n)
+
+	if len(key) == 0 {
+		if !n.terminal {
+			txn.size++
+		}
+		n.terminal = true
+		n.value = value
+		return n
+	}
+
+	c := key[0]
+	child := n.kids.get(c)
+	if child == nil {
+		n.kids.set(c, &node[T]{
+			label:    []byte(key),
+			terminal: true,
+			value:    value,
+			kids:     newChildren[T](),
+		})
+		promoteNode(n, defaultSparseThreshold)
+		txn.size++
+		return n
+	}
+
+	cpl := commonPrefixLen(child.label, []byte(key))
+
+	if cpl == len(child.label) {
+		n.kids.set(c, txn.insert(child, key[cpl:], value))
+		return n
+	}
+
+	child = txn.clone(child)
+	split := &node[T]{label: child.label[:cpl:cpl], kids: newChildren[T]()}
+
+	child.label = child.label[cpl:]
+	split.kids.set(child.label[0], child)
+
+	if cpl == len(key) {
+		split.terminal = true
+		split.value = value
+	} else {
+		rest := key[cpl:]
+		split.kids.set(rest[0], &node[T]{
+			label:    []byte(rest),
+			terminal: true,
+			value:    value,
+			kids:     newChildren[T](),
+		})
+	}
+	promoteNode(split, defaultSparseThreshold)
+
+	txn.size++
+	n.kids.set(c, split)
+	return n
+}
+
+func (txn *Txn[T]) Delete(key string) bool {
+	deleted, root := txn.delete(txn.root, key)
+	if deleted {
+		txn.root = root
+	}
+	return deleted
+}
+
+func (txn *Txn[T]) delete(n *node[T], key string) (bool, *node[T]) {
+	if len(key) == 0 {
+		if !n.terminal {
+			return false, n
+		}
+		n = txn.clone(n)
+		n.terminal = false
+		n.value = *new(T)
+		txn.size--
+		return true, txn.compress(n)
+	}
+
+	c := key[0]
+	child := n.kids.get(c)
+	if child == nil || len(key) < len(child.label) || string(child.label) != key[:len(child.label)] {
+		return false, n
+	}
+
+	deleted, replacement := txn.delete(child, key[len(child.label):])
+	if !deleted {
+		return false, n
+	}
+
+	n = txn.clone(n)
+	if replacement == nil {
+		n.kids.remove(c)
+		demoteNode(n, defaultSparseThreshold)
+	} else {
+		n.kids.set(c, replacement)
+	}
+
+	return true, txn.compress(n)
+}
+
+// compress merges a non-terminal node left with a single child back into
+// that child, cloning it first since its label is about to change.
+func (txn *Txn[T]) compress(n *node[T]) *node[T] {
+	if n.label == nil {
+		// Never merge or drop the root: it carries no label of its own, and
+		// returning nil here would leave txn.root (and the committed tree)
+		// nil instead of a valid, empty node.
+		return n
+	}
+
+	if !n.terminal && n.kids.len() == 0 {
+		return nil
+	}
+
+	if !n.terminal && n.kids.len() == 1 {
+		var only *node[T]
+		n.kids.each(func(_ byte, child *node[T]) { only = child })
+		only = txn.clone(only)
+		only.label = append(append([]byte{}, n.label...), only.label...)
+		return only
+	}
+
+	return n
+}
+
+// Commit finalizes the transaction and returns the new tree. The Txn must
+// not be used afterwards.
+func (txn *Txn[T]) Commit() *ImmutableTree[T] {
+	t := &ImmutableTree[T]{root: txn.root, size: txn.size}
+	txn.cloned = nil
+	return t
+}
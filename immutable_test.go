@@ -0,0 +1,77 @@
+package kradix
+
+import "testing"
+
+func TestTxnCommitIsIsolatedFromSnapshot(t *testing.T) {
+	base := NewImmutable[int]()
+	txn := base.Txn()
+	txn.Insert("a", 1)
+	txn.Insert("ab", 2)
+	v1 := txn.Commit()
+
+	txn2 := v1.Txn()
+	txn2.Insert("a", 99)
+	txn2.Delete("ab")
+	v2 := txn2.Commit()
+
+	// v1 must be unaffected by writes made in txn2 after it branched off.
+	if got, ok := v1.Get("a"); !ok || got != 1 {
+		t.Errorf("v1.Get(\"a\") = (%d, %v), want (1, true)", got, ok)
+	}
+	if got, ok := v1.Get("ab"); !ok || got != 2 {
+		t.Errorf("v1.Get(\"ab\") = (%d, %v), want (2, true)", got, ok)
+	}
+	if v1.Len() != 2 {
+		t.Errorf("v1.Len() = %d, want 2", v1.Len())
+	}
+
+	if got, ok := v2.Get("a"); !ok || got != 99 {
+		t.Errorf("v2.Get(\"a\") = (%d, %v), want (99, true)", got, ok)
+	}
+	if _, ok := v2.Get("ab"); ok {
+		t.Error("v2.Get(\"ab\") found a value after Delete")
+	}
+	if v2.Len() != 1 {
+		t.Errorf("v2.Len() = %d, want 1", v2.Len())
+	}
+}
+
+func TestTxnDeleteLastKeyLeavesTreeUsable(t *testing.T) {
+	base := NewImmutable[int]()
+	txn := base.Txn()
+	txn.Insert("only", 1)
+	v1 := txn.Commit()
+
+	txn2 := v1.Txn()
+	if !txn2.Delete("only") {
+		t.Fatal("Delete(\"only\") = false, want true")
+	}
+	v2 := txn2.Commit()
+
+	if _, ok := v2.Get("only"); ok {
+		t.Error("v2.Get(\"only\") found a value after Delete")
+	}
+	if v2.Len() != 0 {
+		t.Fatalf("v2.Len() = %d, want 0", v2.Len())
+	}
+
+	// Regression: committing an emptied root must not leave it nil.
+	txn3 := v2.Txn()
+	txn3.Insert("next", 2)
+	v3 := txn3.Commit()
+	if got, ok := v3.Get("next"); !ok || got != 2 {
+		t.Fatalf("v3.Get(\"next\") = (%d, %v), want (2, true)", got, ok)
+	}
+}
+
+func TestTxnGetSeesUncommittedWrites(t *testing.T) {
+	base := NewImmutable[int]()
+	txn := base.Txn()
+	txn.Insert("a", 1)
+	if got, ok := txn.Get("a"); !ok || got != 1 {
+		t.Fatalf("txn.Get(\"a\") = (%d, %v), want (1, true)", got, ok)
+	}
+	if _, ok := base.Get("a"); ok {
+		t.Error("base.Get(\"a\") found a value written only in the open txn")
+	}
+}
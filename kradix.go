@@ -1,34 +1,122 @@
 package kradix
 
-import (
-	"bytes"
-	"sync"
-)
+import "hash"
 
 const (
-	branchingFactor = 128
+	// branchingFactor is the number of distinct values a byte can take
+	// (0-255), which bounds how many children a node can have.
+	branchingFactor = 256
+
+	// defaultSparseThreshold is the number of children a node holds in a
+	// sparseChildren list before Insert promotes it to denseChildren.
+	defaultSparseThreshold = 8
 )
 
+// Config tunes a RadixTree's internal storage. The zero value is valid and
+// selects the defaults.
+type Config struct {
+	// SparseThreshold is the number of children a node can hold in its
+	// sparse list before it's promoted to a dense, byte-indexed array; the
+	// node demotes back to sparse once it falls below half that. Zero
+	// selects defaultSparseThreshold.
+	SparseThreshold int
+}
+
+// node is a PATRICIA trie node. label holds the edge byte slice leading to
+// this node from its parent; children are keyed by the first byte of
+// their own label, which is unique among siblings since labels diverge
+// immediately after any shared prefix has been factored into a split node.
 type node[T any] struct {
+	label    []byte
 	terminal bool
 	value    T
-	edges    [branchingFactor]*node[T]
+	kids     childList[T]
+
+	// digest caches this node's Merkle hash for a hashed RadixTree (see
+	// hash.go). It is nil whenever absent or stale; mutations clear it on
+	// every node along the path they touch.
+	digest []byte
 }
 
+// RadixTree is a compressed radix (PATRICIA) tree mapping string keys to
+// values of type T. Edges carry a shared byte-slice prefix instead of a
+// single byte, so long sparse keys such as URLs or file paths no longer
+// cost one node per byte, and nodes switch between sparse and dense child
+// storage as they grow and shrink.
 type RadixTree[T any] struct {
-	root *node[T]
-	pool sync.Pool
+	root      *node[T]
+	size      int
+	threshold int
+
+	// hasher and valueHash are set by NewHashed to enable Root and Diff.
+	// They are nil on a plain tree.
+	hasher    func() hash.Hash
+	valueHash func(T) []byte
 }
 
-func New[T any]() *RadixTree[T] {
+// New returns an empty RadixTree. An optional Config may be passed to tune
+// the sparse/dense child storage threshold.
+func New[T any](cfg ...Config) *RadixTree[T] {
+	threshold := defaultSparseThreshold
+	if len(cfg) > 0 && cfg[0].SparseThreshold > 0 {
+		threshold = cfg[0].SparseThreshold
+	}
 	return &RadixTree[T]{
-		root: &node[T]{},
-		pool: sync.Pool{
-			New: func() interface{} {
-				return &node[T]{}
-			},
-		},
+		root:      &node[T]{kids: newChildren[T]()},
+		threshold: threshold,
+	}
+}
+
+// Len returns the number of keys stored in the tree.
+func (t *RadixTree[T]) Len() int {
+	return t.size
+}
+
+func commonPrefixLen(a, b []byte) int {
+	max := len(a)
+	if len(b) < max {
+		max = len(b)
+	}
+	var i int
+	for i < max && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// promoteNode upgrades n to dense child storage once it has grown past
+// threshold children. It is shared by RadixTree and the immutable tree's
+// Txn so both get the same sparse/dense switch over the same node type.
+func promoteNode[T any](n *node[T], threshold int) {
+	sc, ok := n.kids.(*sparseChildren[T])
+	if !ok || sc.len() <= threshold {
+		return
+	}
+	n.kids = sparseToDense(sc)
+}
+
+// demoteNode downgrades n back to sparse child storage once it has shrunk
+// below half of threshold, with hysteresis against the promotion point to
+// avoid thrashing back and forth on every insert/delete pair.
+func demoteNode[T any](n *node[T], threshold int) {
+	dc, ok := n.kids.(*denseChildren[T])
+	if !ok || dc.len() >= threshold/2 {
+		return
 	}
+	n.kids = denseToSparse(dc)
+}
+
+// promote upgrades n to dense child storage once it has grown past the
+// sparse threshold.
+func (t *RadixTree[T]) promote(n *node[T]) {
+	promoteNode(n, t.threshold)
+}
+
+// demote downgrades n back to sparse child storage once it has shrunk
+// below half the threshold, with hysteresis against the promotion point to avoid
+// thrashing back and forth on every insert/delete pair.
+func (t *RadixTree[T]) demote(n *node[T]) {
+	demoteNode(n, t.threshold)
 }
 
 func (t *RadixTree[T]) Insert(key string, value T) {
@@ -36,172 +124,154 @@ func (t *RadixTree[T]) Insert(key string, value T) {
 }
 
 func (t *RadixTree[T]) insert(n *node[T], key string, value T) *node[T] {
-	if n == nil {
-		n = t.pool.Get().(*node[T])
-	}
+	n.digest = nil
 
 	if len(key) == 0 {
+		if !n.terminal {
+			t.size++
+		}
 		n.terminal = true
 		n.value = value
 		return n
 	}
 
 	c := key[0]
-	child := n.edges[c]
+	child := n.kids.get(c)
 	if child == nil {
-		child = t.pool.Get().(*node[T])
-		n.edges[c] = child
+		n.kids.set(c, &node[T]{
+			label:    []byte(key),
+			terminal: true,
+			value:    value,
+			kids:     newChildren[T](),
+		})
+		t.promote(n)
+		t.size++
+		return n
+	}
+
+	cpl := commonPrefixLen(child.label, []byte(key))
+
+	if cpl == len(child.label) {
+		// The edge label is fully consumed; recurse with the remainder.
+		n.kids.set(c, t.insert(child, key[cpl:], value))
+		return n
+	}
+
+	// Partial match: split child into a new internal node whose label is
+	// the shared prefix, with the old node and the new entry as its two
+	// children, keyed by the byte at which they diverge.
+	split := &node[T]{label: child.label[:cpl:cpl], kids: newChildren[T]()}
+
+	child.label = child.label[cpl:]
+	child.digest = nil
+	split.kids.set(child.label[0], child)
+
+	if cpl == len(key) {
+		split.terminal = true
+		split.value = value
+	} else {
+		rest := key[cpl:]
+		split.kids.set(rest[0], &node[T]{
+			label:    []byte(rest),
+			terminal: true,
+			value:    value,
+			kids:     newChildren[T](),
+		})
 	}
-	child = t.insert(child, key[1:], value)
-	n.edges[c] = child
+	t.promote(split)
 
+	t.size++
+	n.kids.set(c, split)
 	return n
 }
 
 func (t *RadixTree[T]) Get(key string) (T, bool) {
-	n := t.get(t.root, key)
+	n, _ := t.find(key)
 	if n == nil {
 		return *new(T), false
 	}
 	return n.value, n.terminal
 }
 
-func (t *RadixTree[T]) get(n *node[T], key string) *node[T] {
-	if n == nil {
-		return nil
-	}
-
-	if len(key) == 0 {
-		return n
+// find walks the tree for key, returning the node matching it exactly
+// together with the number of key bytes consumed along the way.
+func (t *RadixTree[T]) find(key string) (*node[T], int) {
+	n := t.root
+	var consumed int
+	for len(key) > 0 {
+		child := n.kids.get(key[0])
+		if child == nil {
+			return nil, consumed
+		}
+		if len(key) < len(child.label) || string(child.label) != key[:len(child.label)] {
+			return nil, consumed
+		}
+		key = key[len(child.label):]
+		consumed += len(child.label)
+		n = child
 	}
-
-	c := key[0]
-	return t.get(n.edges[c], key[1:])
+	return n, consumed
 }
 
 func (t *RadixTree[T]) Delete(key string) bool {
-	var deleted bool
-	t.root = t.delete(t.root, key, &deleted)
+	deleted, _ := t.delete(t.root, key)
 	return deleted
 }
 
-func (t *RadixTree[T]) delete(n *node[T], key string, deleted *bool) *node[T] {
-	if n == nil {
-		return nil
-	}
+func (t *RadixTree[T]) delete(n *node[T], key string) (bool, *node[T]) {
+	n.digest = nil
 
 	if len(key) == 0 {
+		if !n.terminal {
+			return false, n
+		}
 		n.terminal = false
 		n.value = *new(T)
-		*deleted = true
-		return t.release(n)
+		t.size--
+		return true, t.compress(n)
 	}
 
 	c := key[0]
-	child := t.delete(n.edges[c], key[1:], deleted)
-	n.edges[c] = child
-
-	if !n.terminal && t.isLeaf(n) && !*deleted {
-		*deleted = true
-		return t.release(n)
+	child := n.kids.get(c)
+	if child == nil || len(key) < len(child.label) || string(child.label) != key[:len(child.label)] {
+		return false, n
 	}
 
-	return n
-}
+	deleted, replacement := t.delete(child, key[len(child.label):])
+	if !deleted {
+		return false, n
+	}
 
-func (t *RadixTree[T]) release(n *node[T]) *node[T] {
-	for i := range n.edges {
-		if n.edges[i] != nil {
-			t.release(n.edges[i])
-			n.edges[i] = nil
-		}
+	if replacement == nil {
+		n.kids.remove(c)
+		t.demote(n)
+	} else {
+		n.kids.set(c, replacement)
 	}
 
-	t.pool.Put(n)
-	return nil
+	return true, t.compress(n)
 }
 
-func (t *RadixTree[T]) isLeaf(n *node[T]) bool {
-	for _, e := range n.edges {
-		if e != nil {
-			return false
-		}
+// compress drops a dead leaf and, when n is a non-terminal node left with
+// exactly one child, merges n's label into that child (a reverse split) so
+// the tree stays maximally compressed after deletions.
+func (t *RadixTree[T]) compress(n *node[T]) *node[T] {
+	if !n.terminal && n.kids.len() == 0 {
+		return nil
 	}
-	return true
-}
 
-func (t *RadixTree[T]) Traverse(f func(string, T)) {
-	var wg sync.WaitGroup
-	stack := make([]*node[T], 0, branchingFactor)
-
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
-
-	wg.Add(1)
-	stack = append(stack, t.root)
-
-	for len(stack) > 0 {
-		n := stack[len(stack)-1]
-		stack = stack[:len(stack)-1]
-
-		if n == nil {
-			continue
-		}
-
-		if n.terminal {
-			f(t.prefix(stack), n.value)
-		}
-
-		if t.hasChildren(n) {
-			var numChildren int
-			var children []*node[T]
-			for _, e := range n.edges {
-				if e != nil {
-					numChildren++
-					children = append(children, e)
-				}
-			}
-			wg.Add(1)
-			go func(children []*node[T], prefixLen int) {
-				for _, child := range children {
-					stack = append(stack, child)
-				}
-				wg.Done()
-			}(children, len(stack))
-
-			// To avoid creating too many goroutines, we only create a new goroutine
-			// once the number of children exceeds a certain threshold.
-			if numChildren > 10 {
-				wg.Wait()
-			}
-		}
+	if n.label == nil {
+		// Never merge the root: it carries no label of its own.
+		return n
 	}
 
-	wg.Done()
-	<-done
-}
-
-func (t *RadixTree[T]) hasChildren(n *node[T]) bool {
-	for _, e := range n.edges {
-		if e != nil {
-			return true
-		}
+	if !n.terminal && n.kids.len() == 1 {
+		var only *node[T]
+		n.kids.each(func(_ byte, child *node[T]) { only = child })
+		only.label = append(append([]byte{}, n.label...), only.label...)
+		only.digest = nil
+		return only
 	}
-	return false
-}
 
-func (t *RadixTree[T]) prefix(stack []*node[T]) string {
-	var buffer bytes.Buffer
-	for _, n := range stack[1:] {
-		for i, e := range n.edges {
-			if e == stack[len(stack)-1] {
-				buffer.WriteByte(byte(i))
-				break
-			}
-		}
-	}
-	return buffer.String()
+	return n
 }
@@ -0,0 +1,108 @@
+package kradix
+
+import "testing"
+
+func TestInsertGetDelete(t *testing.T) {
+	r := New[int]()
+
+	r.Insert("apple", 1)
+	r.Insert("app", 2)
+	r.Insert("application", 3)
+	r.Insert("banana", 4)
+
+	if r.Len() != 4 {
+		t.Fatalf("Len() = %d, want 4", r.Len())
+	}
+
+	cases := []struct {
+		key  string
+		want int
+	}{
+		{"apple", 1},
+		{"app", 2},
+		{"application", 3},
+		{"banana", 4},
+	}
+	for _, c := range cases {
+		got, ok := r.Get(c.key)
+		if !ok || got != c.want {
+			t.Errorf("Get(%q) = (%d, %v), want (%d, true)", c.key, got, ok, c.want)
+		}
+	}
+
+	if _, ok := r.Get("app2"); ok {
+		t.Error("Get(\"app2\") found a value that was never inserted")
+	}
+
+	if !r.Delete("app") {
+		t.Fatal("Delete(\"app\") = false, want true")
+	}
+	if _, ok := r.Get("app"); ok {
+		t.Error("app still present after Delete")
+	}
+	// Siblings that shared app's edge must survive the delete.
+	if got, ok := r.Get("apple"); !ok || got != 1 {
+		t.Errorf("Get(\"apple\") after deleting app = (%d, %v), want (1, true)", got, ok)
+	}
+	if got, ok := r.Get("application"); !ok || got != 3 {
+		t.Errorf("Get(\"application\") after deleting app = (%d, %v), want (3, true)", got, ok)
+	}
+	if r.Len() != 3 {
+		t.Fatalf("Len() after delete = %d, want 3", r.Len())
+	}
+
+	if r.Delete("app") {
+		t.Error("Delete(\"app\") a second time = true, want false")
+	}
+}
+
+func TestInsertSplitsSharedPrefix(t *testing.T) {
+	r := New[string]()
+	r.Insert("romane", "a")
+	r.Insert("romanus", "b")
+	r.Insert("romulus", "c")
+
+	for key, want := range map[string]string{"romane": "a", "romanus": "b", "romulus": "c"} {
+		if got, ok := r.Get(key); !ok || got != want {
+			t.Errorf("Get(%q) = (%q, %v), want (%q, true)", key, got, ok, want)
+		}
+	}
+	if r.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", r.Len())
+	}
+}
+
+func TestDeleteEverythingLeavesUsableTree(t *testing.T) {
+	r := New[int]()
+	r.Insert("only", 1)
+	if !r.Delete("only") {
+		t.Fatal("Delete(\"only\") = false, want true")
+	}
+	if r.Len() != 0 {
+		t.Fatalf("Len() = %d, want 0", r.Len())
+	}
+	// The tree must remain usable after its last key is removed.
+	r.Insert("next", 2)
+	if got, ok := r.Get("next"); !ok || got != 2 {
+		t.Fatalf("Get(\"next\") = (%d, %v), want (2, true)", got, ok)
+	}
+}
+
+func TestWalkVisitsEveryKey(t *testing.T) {
+	r := New[int]()
+	keys := []string{"a", "ab", "abc", "abd", "b"}
+	for i, k := range keys {
+		r.Insert(k, i)
+	}
+
+	seen := map[string]bool{}
+	r.WalkSorted(func(key string, v int) bool {
+		seen[key] = true
+		return true
+	})
+	for _, k := range keys {
+		if !seen[k] {
+			t.Errorf("WalkSorted did not visit %q", k)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package wal
+
+import "time"
+
+// SyncPolicy controls how aggressively a RadixTree's WAL is flushed to
+// stable storage after an append.
+type SyncPolicy interface {
+	isSyncPolicy()
+}
+
+type syncAlways struct{}
+
+func (syncAlways) isSyncPolicy() {}
+
+type syncNever struct{}
+
+func (syncNever) isSyncPolicy() {}
+
+type syncInterval struct{ d time.Duration }
+
+func (syncInterval) isSyncPolicy() {}
+
+// SyncAlways fsyncs the active segment after every Insert and Delete. It is
+// the slowest and safest policy, and the default used by OpenWithWAL.
+func SyncAlways() SyncPolicy { return syncAlways{} }
+
+// SyncNever flushes the segment's in-process buffer after every append but
+// never calls fsync, leaving durability to the OS's own writeback.
+func SyncNever() SyncPolicy { return syncNever{} }
+
+// SyncInterval fsyncs the active segment at most once per d, batching the
+// cost of fsync across all appends within that window.
+func SyncInterval(d time.Duration) SyncPolicy { return syncInterval{d: d} }
@@ -0,0 +1,102 @@
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+type opCode byte
+
+const (
+	opInsert opCode = 1
+	opDelete opCode = 2
+)
+
+var errCorruptRecord = errors.New("wal: corrupt record")
+
+// record is a single WAL entry describing one Insert or Delete.
+type record struct {
+	op    opCode
+	key   string
+	value []byte // nil for deletes
+}
+
+// encode frames r as crc32(payload) | len(payload) | payload, where payload
+// is op | keyLen | key | valueLen | value.
+func (r record) encode() []byte {
+	payload := make([]byte, 0, 1+4+len(r.key)+4+len(r.value))
+	payload = append(payload, byte(r.op))
+	payload = appendUint32Prefixed(payload, []byte(r.key))
+	payload = appendUint32Prefixed(payload, r.value)
+
+	buf := make([]byte, 8+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], crc32.ChecksumIEEE(payload))
+	binary.BigEndian.PutUint32(buf[4:8], uint32(len(payload)))
+	copy(buf[8:], payload)
+	return buf
+}
+
+// readRecord reads and CRC-validates one framed record from r. A clean end
+// of file is reported as io.EOF; a write torn by a crash partway through a
+// record is reported as io.ErrUnexpectedEOF so callers can stop replay
+// there instead of failing to open the WAL.
+func readRecord(r io.Reader) (record, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return record{}, err
+	}
+	wantCRC := binary.BigEndian.Uint32(header[0:4])
+	length := binary.BigEndian.Uint32(header[4:8])
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return record{}, err
+	}
+	if crc32.ChecksumIEEE(payload) != wantCRC {
+		return record{}, errCorruptRecord
+	}
+	if len(payload) < 1 {
+		return record{}, errCorruptRecord
+	}
+
+	op := opCode(payload[0])
+	key, rest, err := readUint32Prefixed(payload[1:])
+	if err != nil {
+		return record{}, err
+	}
+	value, _, err := readUint32Prefixed(rest)
+	if err != nil {
+		return record{}, err
+	}
+
+	rec := record{op: op, key: string(key)}
+	if op == opInsert {
+		rec.value = value
+	}
+	return rec, nil
+}
+
+func appendUint32Prefixed(buf, data []byte) []byte {
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(data)))
+	buf = append(buf, lenBuf[:]...)
+	buf = append(buf, data...)
+	return buf
+}
+
+func readUint32Prefixed(buf []byte) (data, rest []byte, err error) {
+	if len(buf) < 4 {
+		return nil, nil, errCorruptRecord
+	}
+	n := binary.BigEndian.Uint32(buf[0:4])
+	buf = buf[4:]
+	if uint32(len(buf)) < n {
+		return nil, nil, errCorruptRecord
+	}
+	return buf[:n], buf[n:], nil
+}
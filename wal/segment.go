@@ -0,0 +1,81 @@
+package wal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func segmentPath(dir string, seq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("wal-%020d.log", seq))
+}
+
+func snapshotPath(dir string, seq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("snapshot-%020d.kr", seq))
+}
+
+func parseSequencedName(name, prefix, suffix string) (uint64, bool) {
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
+
+func parseSegmentName(name string) (uint64, bool) {
+	return parseSequencedName(name, "wal-", ".log")
+}
+
+func parseSnapshotName(name string) (uint64, bool) {
+	return parseSequencedName(name, "snapshot-", ".kr")
+}
+
+// segment is the currently active append-only WAL file.
+type segment struct {
+	seq  uint64
+	file *os.File
+	w    *bufio.Writer
+	size int64
+}
+
+func createSegment(dir string, seq uint64) (*segment, error) {
+	f, err := os.OpenFile(segmentPath(dir, seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &segment{seq: seq, file: f, w: bufio.NewWriter(f)}, nil
+}
+
+func (s *segment) append(rec record) error {
+	buf := rec.encode()
+	if _, err := s.w.Write(buf); err != nil {
+		return err
+	}
+	s.size += int64(len(buf))
+	return nil
+}
+
+func (s *segment) flush() error {
+	return s.w.Flush()
+}
+
+func (s *segment) sync() error {
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *segment) close() error {
+	if err := s.w.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
@@ -0,0 +1,433 @@
+// Package wal adds write-ahead-log and snapshot persistence on top of
+// kradix.RadixTree so a tree can survive process restarts.
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kamalshkeir/kradix"
+)
+
+// defaultSegmentThreshold is the WAL segment size at which the append that
+// reaches it rotates to a fresh segment and snapshots the tree; the
+// background manager also checks it once a second.
+const defaultSegmentThreshold = 16 << 20 // 16 MiB
+
+// RadixTree is a kradix.RadixTree[T] backed by a write-ahead log: every
+// Insert and Delete is appended as a framed, CRC-checked record to an
+// on-disk segment before being applied in memory, so OpenWithWAL can
+// rebuild identical state after a crash or restart by replaying the log on
+// top of the most recent snapshot.
+type RadixTree[T any] struct {
+	mu     sync.Mutex
+	tree   *kradix.RadixTree[T]
+	dir    string
+	decode func([]byte) (T, error)
+	encode func(T) ([]byte, error)
+	policy SyncPolicy
+
+	active  *segment
+	nextSeq uint64
+
+	lastSync time.Time
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+	wg        sync.WaitGroup
+}
+
+// OpenWithWAL opens the durable tree rooted at dir, creating it if absent.
+// If a snapshot file is present, it is loaded first by streaming its
+// (keyLen, key, valueLen, value) records into Insert; any WAL segments
+// written after that snapshot are then replayed on top of it.
+func OpenWithWAL[T any](dir string, decode func([]byte) (T, error), encode func(T) ([]byte, error)) (*RadixTree[T], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	t := &RadixTree[T]{
+		tree:    kradix.New[T](),
+		dir:     dir,
+		decode:  decode,
+		encode:  encode,
+		policy:  SyncAlways(),
+		closeCh: make(chan struct{}),
+	}
+
+	snapSeq, err := t.loadLatestSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	segSeqs, err := t.replaySegments(snapSeq)
+	if err != nil {
+		return nil, err
+	}
+
+	t.nextSeq = snapSeq
+	if n := len(segSeqs); n > 0 && segSeqs[n-1] > t.nextSeq {
+		t.nextSeq = segSeqs[n-1]
+	}
+	t.nextSeq++
+
+	active, err := createSegment(dir, t.nextSeq)
+	if err != nil {
+		return nil, err
+	}
+	t.active = active
+
+	t.wg.Add(1)
+	go t.manage()
+
+	return t, nil
+}
+
+// SetSyncPolicy changes how aggressively future appends are fsynced.
+func (t *RadixTree[T]) SetSyncPolicy(p SyncPolicy) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.policy = p
+}
+
+// Insert durably records key=value, appending a WAL record before applying
+// it to the in-memory tree.
+func (t *RadixTree[T]) Insert(key string, value T) error {
+	data, err := t.encode(value)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.append(record{op: opInsert, key: key, value: data}); err != nil {
+		return err
+	}
+	t.tree.Insert(key, value)
+	return nil
+}
+
+// Delete durably removes key, appending a WAL record before applying it to
+// the in-memory tree.
+func (t *RadixTree[T]) Delete(key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.append(record{op: opDelete, key: key}); err != nil {
+		return err
+	}
+	t.tree.Delete(key)
+	return nil
+}
+
+func (t *RadixTree[T]) Get(key string) (T, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tree.Get(key)
+}
+
+func (t *RadixTree[T]) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tree.Len()
+}
+
+// Close stops the background manager and flushes the active segment.
+func (t *RadixTree[T]) Close() error {
+	t.closeOnce.Do(func() { close(t.closeCh) })
+	t.wg.Wait()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.active.close()
+}
+
+func (t *RadixTree[T]) append(rec record) error {
+	if err := t.active.append(rec); err != nil {
+		return err
+	}
+
+	switch p := t.policy.(type) {
+	case syncAlways:
+		if err := t.active.sync(); err != nil {
+			return err
+		}
+	case syncInterval:
+		if time.Since(t.lastSync) >= p.d {
+			if err := t.active.sync(); err != nil {
+				return err
+			}
+			t.lastSync = time.Now()
+		}
+	default: // syncNever
+		if err := t.active.flush(); err != nil {
+			return err
+		}
+	}
+
+	if t.active.size >= defaultSegmentThreshold {
+		return t.checkpointLocked()
+	}
+	return nil
+}
+
+// manage periodically checkpoints the tree: rotating the active segment
+// and writing a fresh snapshot once the segment has grown past the
+// rotation threshold, after which WAL segments folded into that snapshot
+// are deleted.
+func (t *RadixTree[T]) manage() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.closeCh:
+			return
+		case <-ticker.C:
+			t.mu.Lock()
+			needsCheckpoint := t.active.size >= defaultSegmentThreshold
+			t.mu.Unlock()
+			if needsCheckpoint {
+				_ = t.checkpoint()
+			}
+		}
+	}
+}
+
+func (t *RadixTree[T]) checkpoint() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.checkpointLocked()
+}
+
+// checkpointLocked closes the active segment, snapshots the tree under that
+// segment's sequence number, opens a new active segment at the next one,
+// and deletes WAL segments and snapshots made obsolete by it. t.mu must be
+// held.
+func (t *RadixTree[T]) checkpointLocked() error {
+	oldSeq := t.active.seq
+	if err := t.active.close(); err != nil {
+		return err
+	}
+
+	snapSeq := t.nextSeq
+	if err := t.writeSnapshot(snapSeq); err != nil {
+		return err
+	}
+
+	t.nextSeq++
+	active, err := createSegment(t.dir, t.nextSeq)
+	if err != nil {
+		return err
+	}
+	t.active = active
+
+	t.cleanup(snapSeq, oldSeq)
+	return nil
+}
+
+func (t *RadixTree[T]) writeSnapshot(seq uint64) error {
+	tmp := snapshotPath(t.dir, seq) + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	var walkErr error
+	t.tree.WalkSorted(func(key string, v T) bool {
+		data, err := t.encode(v)
+		if err != nil {
+			walkErr = err
+			return false
+		}
+		if err := writeLengthPrefixedPair(w, []byte(key), data); err != nil {
+			walkErr = err
+			return false
+		}
+		return true
+	})
+	if walkErr != nil {
+		f.Close()
+		os.Remove(tmp)
+		return walkErr
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, snapshotPath(t.dir, seq))
+}
+
+// cleanup removes snapshots older than snapSeq and WAL segments folded
+// into it (sequence numbers up to and including throughSeg).
+func (t *RadixTree[T]) cleanup(snapSeq, throughSeg uint64) {
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if seq, ok := parseSnapshotName(e.Name()); ok && seq < snapSeq {
+			os.Remove(filepath.Join(t.dir, e.Name()))
+			continue
+		}
+		if seq, ok := parseSegmentName(e.Name()); ok && seq <= throughSeg {
+			os.Remove(filepath.Join(t.dir, e.Name()))
+		}
+	}
+}
+
+func (t *RadixTree[T]) loadLatestSnapshot() (uint64, error) {
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var best uint64
+	var bestName string
+	for _, e := range entries {
+		if seq, ok := parseSnapshotName(e.Name()); ok && (bestName == "" || seq > best) {
+			best, bestName = seq, e.Name()
+		}
+	}
+	if bestName == "" {
+		return 0, nil
+	}
+
+	f, err := os.Open(filepath.Join(t.dir, bestName))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		key, value, err := readLengthPrefixedPair(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		v, err := t.decode(value)
+		if err != nil {
+			return 0, err
+		}
+		t.tree.Insert(string(key), v)
+	}
+	return best, nil
+}
+
+// replaySegments applies, in sequence order, every WAL segment written
+// after snapSeq, returning the sequence numbers it replayed.
+func (t *RadixTree[T]) replaySegments(snapSeq uint64) ([]uint64, error) {
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var seqs []uint64
+	for _, e := range entries {
+		if seq, ok := parseSegmentName(e.Name()); ok && seq > snapSeq {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	for _, seq := range seqs {
+		if err := t.replaySegment(seq); err != nil {
+			return nil, err
+		}
+	}
+	return seqs, nil
+}
+
+func (t *RadixTree[T]) replaySegment(seq uint64) error {
+	f, err := os.Open(segmentPath(t.dir, seq))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		rec, err := readRecord(r)
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			// A torn record at the tail is an expected sign of a crash
+			// mid-append; stop replay here rather than failing to open.
+			return nil
+		default:
+			return err
+		}
+
+		switch rec.op {
+		case opInsert:
+			v, err := t.decode(rec.value)
+			if err != nil {
+				return err
+			}
+			t.tree.Insert(rec.key, v)
+		case opDelete:
+			t.tree.Delete(rec.key)
+		}
+	}
+}
+
+func writeLengthPrefixedPair(w io.Writer, key, value []byte) error {
+	if err := writeUint32Prefixed(w, key); err != nil {
+		return err
+	}
+	return writeUint32Prefixed(w, value)
+}
+
+func writeUint32Prefixed(w io.Writer, data []byte) error {
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(data)))
+	if _, err := w.Write(lenBuf[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func readLengthPrefixedPair(r io.Reader) (key, value []byte, err error) {
+	if key, err = readUint32PrefixedFrom(r); err != nil {
+		return nil, nil, err
+	}
+	if value, err = readUint32PrefixedFrom(r); err != nil {
+		return nil, nil, err
+	}
+	return key, value, nil
+}
+
+func readUint32PrefixedFrom(r io.Reader) ([]byte, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(lenBuf[:])
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,87 @@
+package wal
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func encodeInt(v int) ([]byte, error) {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(v))
+	return buf, nil
+}
+
+func decodeInt(b []byte) (int, error) {
+	return int(binary.BigEndian.Uint64(b)), nil
+}
+
+func TestOpenWithWALPersistsAcrossReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	t1, err := OpenWithWAL[int](dir, decodeInt, encodeInt)
+	if err != nil {
+		t.Fatalf("OpenWithWAL: %v", err)
+	}
+	if err := t1.Insert("a", 1); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := t1.Insert("b", 2); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := t1.Delete("a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := t1.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	t2, err := OpenWithWAL[int](dir, decodeInt, encodeInt)
+	if err != nil {
+		t.Fatalf("OpenWithWAL (reopen): %v", err)
+	}
+	defer t2.Close()
+
+	if _, ok := t2.Get("a"); ok {
+		t.Error("Get(\"a\") found a value that was deleted before close")
+	}
+	if got, ok := t2.Get("b"); !ok || got != 2 {
+		t.Errorf("Get(\"b\") = (%d, %v), want (2, true)", got, ok)
+	}
+	if t2.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", t2.Len())
+	}
+}
+
+func TestOpenWithWALReplaysAfterSnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	t1, err := OpenWithWAL[int](dir, decodeInt, encodeInt)
+	if err != nil {
+		t.Fatalf("OpenWithWAL: %v", err)
+	}
+	if err := t1.Insert("a", 1); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := t1.checkpoint(); err != nil {
+		t.Fatalf("checkpoint: %v", err)
+	}
+	if err := t1.Insert("b", 2); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := t1.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	t2, err := OpenWithWAL[int](dir, decodeInt, encodeInt)
+	if err != nil {
+		t.Fatalf("OpenWithWAL (reopen): %v", err)
+	}
+	defer t2.Close()
+
+	if got, ok := t2.Get("a"); !ok || got != 1 {
+		t.Errorf("Get(\"a\") = (%d, %v), want (1, true)", got, ok)
+	}
+	if got, ok := t2.Get("b"); !ok || got != 2 {
+		t.Errorf("Get(\"b\") = (%d, %v), want (2, true)", got, ok)
+	}
+}
@@ -0,0 +1,164 @@
+package kradix
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// WalkSorted visits every key in the tree in lexicographic order, calling f
+// for each one. f may return false to stop the walk early.
+func (t *RadixTree[T]) WalkSorted(f func(key string, v T) bool) {
+	t.walkFrom(t.root, make([]byte, 0, 64), f)
+}
+
+// WalkPrefix visits every key stored under prefix, in lexicographic order,
+// calling f for each one. f may return false to stop the walk early.
+// Because edges carry whole shared labels, WalkPrefix can skip straight
+// past subtrees that don't match prefix instead of descending byte by byte.
+func (t *RadixTree[T]) WalkPrefix(prefix string, f func(key string, v T) bool) {
+	n := t.root
+	matched := make([]byte, 0, len(prefix))
+
+	for len(prefix) > 0 {
+		child := n.kids.get(prefix[0])
+		if child == nil {
+			return
+		}
+		switch {
+		case len(prefix) >= len(child.label):
+			if string(child.label) != prefix[:len(child.label)] {
+				return
+			}
+			matched = append(matched, child.label...)
+			prefix = prefix[len(child.label):]
+			n = child
+		default:
+			// The remaining prefix ends partway through this edge; it still
+			// matches as long as it's a prefix of the label.
+			if string(child.label[:len(prefix)]) != prefix {
+				return
+			}
+			matched = append(matched, child.label...)
+			n = child
+			prefix = ""
+		}
+	}
+
+	t.walkFrom(n, matched, f)
+}
+
+// walkFrom is the sequential, lexicographically-ordered walker shared by
+// WalkSorted and WalkPrefix. It carries the accumulated key in a single
+// []byte buffer, pushing each edge's label on descent and popping it back
+// off on return, rather than allocating a new prefix per node.
+func (t *RadixTree[T]) walkFrom(n *node[T], prefix []byte, f func(key string, v T) bool) bool {
+	if n.terminal {
+		if !f(string(prefix), n.value) {
+			return false
+		}
+	}
+	var stopped bool
+	n.kids.each(func(_ byte, child *node[T]) {
+		if stopped {
+			return
+		}
+		prefix = append(prefix, child.label...)
+		if !t.walkFrom(child, prefix, f) {
+			stopped = true
+		}
+		prefix = prefix[:len(prefix)-len(child.label)]
+	})
+	return !stopped
+}
+
+// LongestPrefix finds the longest key in the tree that is itself a prefix
+// of key, returning it along with its value. The third return value is
+// false if no key in the tree prefixes key.
+func (t *RadixTree[T]) LongestPrefix(key string) (string, T, bool) {
+	n := t.root
+	var matched []byte
+	var bestKey string
+	var bestVal T
+	var found bool
+
+	if n.terminal {
+		bestVal, found = n.value, true
+	}
+
+	for len(key) > 0 {
+		child := n.kids.get(key[0])
+		if child == nil || len(key) < len(child.label) || string(child.label) != key[:len(child.label)] {
+			break
+		}
+		matched = append(matched, child.label...)
+		key = key[len(child.label):]
+		n = child
+		if n.terminal {
+			bestKey = string(matched)
+			bestVal = n.value
+			found = true
+		}
+	}
+
+	return bestKey, bestVal, found
+}
+
+// WalkParallel visits every key in the tree, calling f for each one. Work
+// is fanned out across a pool of goroutines bounded by runtime.GOMAXPROCS,
+// implemented as a buffered channel used as a counting semaphore; once the
+// pool is saturated, remaining subtrees are walked inline on the calling
+// goroutine instead of growing it further. f may return false to stop the
+// walk early, and the walk also stops once ctx is done. Unlike WalkSorted,
+// key order across different subtrees is not guaranteed.
+func (t *RadixTree[T]) WalkParallel(ctx context.Context, f func(key string, v T) bool) {
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	t.walkParallel(ctx, t.root, nil, f, sem, &stop, &wg)
+	wg.Wait()
+}
+
+func (t *RadixTree[T]) walkParallel(ctx context.Context, n *node[T], prefix []byte, f func(string, T) bool, sem chan struct{}, stop *atomic.Bool, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	if stop.Load() {
+		return
+	}
+	select {
+	case <-ctx.Done():
+		stop.Store(true)
+		return
+	default:
+	}
+
+	if n.terminal {
+		if !f(string(prefix), n.value) {
+			stop.Store(true)
+			return
+		}
+	}
+
+	n.kids.each(func(_ byte, child *node[T]) {
+		if stop.Load() {
+			return
+		}
+		childPrefix := append(append([]byte{}, prefix...), child.label...)
+
+		wg.Add(1)
+		select {
+		case sem <- struct{}{}:
+			go func(child *node[T], prefix []byte) {
+				defer func() { <-sem }()
+				t.walkParallel(ctx, child, prefix, f, sem, stop, wg)
+			}(child, childPrefix)
+		default:
+			// Pool saturated: do this subtree inline instead of spawning
+			// an unbounded number of goroutines.
+			t.walkParallel(ctx, child, childPrefix, f, sem, stop, wg)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package kradix
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestWalkSortedOrder(t *testing.T) {
+	r := New[int]()
+	keys := []string{"banana", "apple", "app", "application", "band"}
+	for i, k := range keys {
+		r.Insert(k, i)
+	}
+
+	var got []string
+	r.WalkSorted(func(key string, v int) bool {
+		got = append(got, key)
+		return true
+	})
+
+	want := append([]string{}, keys...)
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Fatalf("WalkSorted visited %d keys, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("WalkSorted()[%d] = %q, want %q (full: %v)", i, got[i], want[i], got)
+		}
+	}
+}
+
+func TestWalkSortedEarlyStop(t *testing.T) {
+	r := New[int]()
+	r.Insert("a", 1)
+	r.Insert("b", 2)
+	r.Insert("c", 3)
+
+	var seen int
+	r.WalkSorted(func(key string, v int) bool {
+		seen++
+		return false
+	})
+	if seen != 1 {
+		t.Fatalf("WalkSorted visited %d keys after f returned false, want 1", seen)
+	}
+}
+
+func TestWalkPrefix(t *testing.T) {
+	r := New[int]()
+	for i, k := range []string{"app", "apple", "application", "banana"} {
+		r.Insert(k, i)
+	}
+
+	var got []string
+	r.WalkPrefix("app", func(key string, v int) bool {
+		got = append(got, key)
+		return true
+	})
+	sort.Strings(got)
+	want := []string{"app", "apple", "application"}
+	if len(got) != len(want) {
+		t.Fatalf("WalkPrefix(\"app\") = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("WalkPrefix(\"app\") = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestLongestPrefix(t *testing.T) {
+	r := New[int]()
+	r.Insert("a", 1)
+	r.Insert("ab", 2)
+	r.Insert("abc", 3)
+
+	key, v, ok := r.LongestPrefix("abcdef")
+	if !ok || key != "abc" || v != 3 {
+		t.Fatalf("LongestPrefix(\"abcdef\") = (%q, %d, %v), want (\"abc\", 3, true)", key, v, ok)
+	}
+
+	if _, _, ok := r.LongestPrefix("xyz"); ok {
+		t.Error("LongestPrefix(\"xyz\") found a match that doesn't exist")
+	}
+}
+
+func TestWalkParallelVisitsEveryKey(t *testing.T) {
+	r := New[int]()
+	want := map[string]int{}
+	for i := 0; i < 200; i++ {
+		k := string(rune('a'+i%26)) + string(rune('a'+(i/26)%26)) + string(rune('a'+i%13))
+		r.Insert(k, i)
+		want[k] = i
+	}
+
+	var mu sync.Mutex
+	got := map[string]int{}
+	r.WalkParallel(context.Background(), func(key string, v int) bool {
+		mu.Lock()
+		got[key] = v
+		mu.Unlock()
+		return true
+	})
+
+	if len(got) != len(want) {
+		t.Fatalf("WalkParallel visited %d keys, want %d", len(got), len(want))
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("WalkParallel value for %q = %d, want %d", k, got[k], v)
+		}
+	}
+}